@@ -0,0 +1,72 @@
+package main
+
+import "sync"
+
+// CustomerState is the running state a rule tree may need to evaluate
+// milestone conditions, e.g. "3rd grocery transaction this month".
+type CustomerState struct {
+	TxnCountByCategory map[string]int
+}
+
+// customerEntry pairs a customer's running state with the lock that
+// makes read-eval-record atomic for that customer alone. Locking here
+// instead of on the tracker means unrelated customers' transactions
+// never wait on each other.
+type customerEntry struct {
+	mu    sync.Mutex
+	state CustomerState
+}
+
+// CustomerStateTracker records per-customer transaction counts. Each
+// customer gets its own lock (see customerEntry) so EvaluateAndRecord is
+// atomic per customer without serializing the whole service's
+// /create-transaction traffic through a single mutex.
+type CustomerStateTracker struct {
+	mu      sync.Mutex // guards entries only, not the state inside them
+	entries map[string]*customerEntry
+}
+
+// NewCustomerStateTracker returns an empty tracker.
+func NewCustomerStateTracker() *CustomerStateTracker {
+	return &CustomerStateTracker{entries: make(map[string]*customerEntry)}
+}
+
+// entryFor returns customerID's entry, creating it if this is the first
+// transaction seen for that customer.
+func (t *CustomerStateTracker) entryFor(customerID string) *customerEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[customerID]
+	if !ok {
+		entry = &customerEntry{state: CustomerState{TxnCountByCategory: map[string]int{}}}
+		t.entries[customerID] = entry
+	}
+	return entry
+}
+
+func copyState(state CustomerState) CustomerState {
+	counts := make(map[string]int, len(state.TxnCountByCategory))
+	for category, count := range state.TxnCountByCategory {
+		counts[category] = count
+	}
+	return CustomerState{TxnCountByCategory: counts}
+}
+
+// EvaluateAndRecord reads customerID's current state, calls fn with it,
+// then increments the count for category — all under that customer's
+// own lock, so no other call for the same customer can observe the
+// state in between, while other customers proceed uncontended. category's
+// count is incremented regardless of whether fn returns an error,
+// matching the previous record-every-transaction behavior.
+func (t *CustomerStateTracker) EvaluateAndRecord(customerID, category string, fn func(CustomerState) (*Offer, error)) (*Offer, error) {
+	entry := t.entryFor(customerID)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	offer, err := fn(copyState(entry.state))
+	entry.state.TxnCountByCategory[category]++
+
+	return offer, err
+}
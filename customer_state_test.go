@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestEvaluateAndRecordSerializesMilestoneChecks reproduces the
+// double-credit bug: two concurrent calls for the same customer must
+// not both observe the same pre-increment milestone count.
+func TestEvaluateAndRecordSerializesMilestoneChecks(t *testing.T) {
+	tracker := NewCustomerStateTracker()
+	tracker.entryFor("cust-1").state = CustomerState{TxnCountByCategory: map[string]int{"grocery": 2}}
+
+	const milestone = 3
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	qualified := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = tracker.EvaluateAndRecord("cust-1", "grocery", func(state CustomerState) (*Offer, error) {
+				if state.TxnCountByCategory["grocery"]+1 == milestone {
+					mu.Lock()
+					qualified++
+					mu.Unlock()
+				}
+				return nil, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if qualified != 1 {
+		t.Fatalf("expected exactly 1 call to qualify for the milestone, got %d", qualified)
+	}
+}
+
+// TestEvaluateAndRecordDoesNotSerializeAcrossCustomers confirms two
+// different customers' EvaluateAndRecord calls don't contend on the same
+// lock: each customer's own call must observe its own pre-increment
+// count, not one incremented by the other customer's concurrent call.
+func TestEvaluateAndRecordDoesNotSerializeAcrossCustomers(t *testing.T) {
+	tracker := NewCustomerStateTracker()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	observed := make(map[string]int)
+
+	for i := 0; i < 2; i++ {
+		customerID := []string{"cust-a", "cust-b"}[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, _ = tracker.EvaluateAndRecord(customerID, "grocery", func(state CustomerState) (*Offer, error) {
+					mu.Lock()
+					observed[customerID]++
+					mu.Unlock()
+					return nil, nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if observed["cust-a"] != 20 || observed["cust-b"] != 20 {
+		t.Fatalf("expected 20 evaluations per customer, got %+v", observed)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors returned by the offer evaluation and store layers.
+// Handlers map these to HTTP responses via writeError instead of
+// constructing ad-hoc error strings per call site.
+var (
+	ErrNoApplicableOffer  = errors.New("no applicable offer found")
+	ErrOfferNotFound      = errors.New("offer not found")
+	ErrInvalidTransaction = errors.New("invalid transaction data")
+	ErrInvalidOffer       = errors.New("invalid offer data")
+)
+
+// errorResponse is the JSON envelope returned for every 4xx/5xx response.
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+// statusForError maps a sentinel error to the HTTP status it should
+// produce. Unrecognized errors default to 500.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrNoApplicableOffer), errors.Is(err, ErrOfferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidTransaction), errors.Is(err, ErrInvalidOffer):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode returns the stable machine-readable code for err, used in
+// the JSON error envelope.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrNoApplicableOffer):
+		return "no_applicable_offer"
+	case errors.Is(err, ErrOfferNotFound):
+		return "offer_not_found"
+	case errors.Is(err, ErrInvalidTransaction):
+		return "invalid_transaction"
+	case errors.Is(err, ErrInvalidOffer):
+		return "invalid_offer"
+	default:
+		return "internal_error"
+	}
+}
+
+// writeError writes a JSON error envelope for err. details carries any
+// extra context, e.g. the underlying error from a store call; it's
+// omitted from the envelope when empty.
+func writeError(w http.ResponseWriter, err error, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusForError(err))
+	json.NewEncoder(w).Encode(errorResponse{
+		Code:    errorCode(err),
+		Message: err.Error(),
+		Details: details,
+	})
+}
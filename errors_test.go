@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{ErrNoApplicableOffer, http.StatusNotFound, "no_applicable_offer"},
+		{ErrOfferNotFound, http.StatusNotFound, "offer_not_found"},
+		{ErrInvalidTransaction, http.StatusBadRequest, "invalid_transaction"},
+		{ErrInvalidOffer, http.StatusBadRequest, "invalid_offer"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeError(rec, tt.err, "")
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%v: got status %d, want %d", tt.err, rec.Code, tt.wantStatus)
+		}
+
+		var body errorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if body.Code != tt.wantCode {
+			t.Errorf("%v: got code %q, want %q", tt.err, body.Code, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// OfferDecision is the outcome of evaluating a transaction, returned to
+// the caller so it can reconcile which offer (if any) was applied.
+type OfferDecision struct {
+	TxnID        string    `json:"txnId"`
+	OfferID      string    `json:"offerId"`
+	RewardAmount float64   `json:"rewardAmount"`
+	AppliedAt    time.Time `json:"appliedAt"`
+}
+
+type idempotencyEntry struct {
+	txnID     string
+	decision  OfferDecision
+	expiresAt time.Time
+}
+
+// inflightCall tracks a TxnID that's currently being evaluated, so
+// concurrent duplicates can wait for that evaluation instead of racing
+// it. done is closed once the claiming caller calls Put or Release.
+type inflightCall struct {
+	done     chan struct{}
+	decision OfferDecision
+	found    bool
+}
+
+// IdempotencyCache remembers the OfferDecision computed for each TxnID
+// so a retried /create-transaction call returns the original decision
+// instead of re-evaluating (and potentially double-applying) an offer.
+// It's a size-bounded LRU with a TTL on top, since an unbounded map of
+// every TxnID ever seen would leak memory. Claim/Put/Release additionally
+// serialize concurrent callers sharing the same TxnID (e.g. a client
+// retrying while the first request is still in flight) onto a single
+// evaluation instead of a plain Get/Put, which would let both race past
+// a miss and both apply the offer.
+type IdempotencyCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+	inflight map[string]*inflightCall
+}
+
+// NewIdempotencyCache returns a cache holding at most capacity entries,
+// each valid for ttl after it was written.
+func NewIdempotencyCache(capacity int, ttl time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// Get returns the decision previously recorded for txnID, if any and
+// not yet expired.
+func (c *IdempotencyCache) Get(txnID string) (OfferDecision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[txnID]
+	if !ok {
+		return OfferDecision{}, false
+	}
+
+	entry := elem.Value.(*idempotencyEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, txnID)
+		return OfferDecision{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.decision, true
+}
+
+// Claim checks for a previously recorded decision for txnID. If one
+// exists, it's returned (found=true, claimed=false). If another call for
+// the same txnID is already in flight, Claim blocks until that call
+// finishes via Put or Release and returns its outcome (claimed=false).
+// Otherwise the caller claims txnID (claimed=true) and must follow up
+// with exactly one call to Put or Release to hand the result to any
+// waiters.
+func (c *IdempotencyCache) Claim(txnID string) (decision OfferDecision, found bool, claimed bool) {
+	c.mu.Lock()
+
+	if elem, ok := c.entries[txnID]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		if time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(elem)
+			decision = entry.decision
+			c.mu.Unlock()
+			return decision, true, false
+		}
+		c.order.Remove(elem)
+		delete(c.entries, txnID)
+	}
+
+	if call, ok := c.inflight[txnID]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.decision, call.found, false
+	}
+
+	c.inflight[txnID] = &inflightCall{done: make(chan struct{})}
+	c.mu.Unlock()
+	return OfferDecision{}, false, true
+}
+
+// Release abandons a claim made via Claim without recording a decision,
+// e.g. because evaluation failed. Any caller blocked in Claim for the
+// same txnID is woken with found=false so it can claim and retry the
+// evaluation itself.
+func (c *IdempotencyCache) Release(txnID string) {
+	c.mu.Lock()
+	call, ok := c.inflight[txnID]
+	if ok {
+		delete(c.inflight, txnID)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		close(call.done)
+	}
+}
+
+// Put records decision for txnID, evicting the least recently used
+// entry if the cache is at capacity, and resolves any claim on txnID so
+// callers blocked in Claim receive decision.
+func (c *IdempotencyCache) Put(txnID string, decision OfferDecision) {
+	c.mu.Lock()
+
+	if elem, ok := c.entries[txnID]; ok {
+		elem.Value.(*idempotencyEntry).decision = decision
+		elem.Value.(*idempotencyEntry).expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+	} else {
+		entry := &idempotencyEntry{txnID: txnID, decision: decision, expiresAt: time.Now().Add(c.ttl)}
+		elem := c.order.PushFront(entry)
+		c.entries[txnID] = elem
+
+		if c.order.Len() > c.capacity {
+			oldest := c.order.Back()
+			if oldest != nil {
+				c.order.Remove(oldest)
+				delete(c.entries, oldest.Value.(*idempotencyEntry).txnID)
+			}
+		}
+	}
+
+	call, ok := c.inflight[txnID]
+	if ok {
+		delete(c.inflight, txnID)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		call.decision = decision
+		call.found = true
+		close(call.done)
+	}
+}
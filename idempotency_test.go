@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCacheGetPut(t *testing.T) {
+	cache := NewIdempotencyCache(2, time.Hour)
+
+	if _, ok := cache.Get("txn-1"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	want := OfferDecision{TxnID: "txn-1", OfferID: "offer-1", RewardAmount: 10}
+	cache.Put("txn-1", want)
+
+	got, ok := cache.Get("txn-1")
+	if !ok || got != want {
+		t.Fatalf("got %+v, ok=%v; want %+v", got, ok, want)
+	}
+}
+
+func TestIdempotencyCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewIdempotencyCache(2, time.Hour)
+
+	cache.Put("txn-1", OfferDecision{TxnID: "txn-1"})
+	cache.Put("txn-2", OfferDecision{TxnID: "txn-2"})
+	cache.Get("txn-1") // touch txn-1 so txn-2 becomes the LRU entry
+	cache.Put("txn-3", OfferDecision{TxnID: "txn-3"})
+
+	if _, ok := cache.Get("txn-2"); ok {
+		t.Fatal("expected txn-2 to have been evicted")
+	}
+	if _, ok := cache.Get("txn-1"); !ok {
+		t.Fatal("expected txn-1 to still be cached")
+	}
+	if _, ok := cache.Get("txn-3"); !ok {
+		t.Fatal("expected txn-3 to still be cached")
+	}
+}
+
+func TestIdempotencyCacheExpires(t *testing.T) {
+	cache := NewIdempotencyCache(10, time.Millisecond)
+	cache.Put("txn-1", OfferDecision{TxnID: "txn-1"})
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := cache.Get("txn-1"); ok {
+		t.Fatal("expected entry to have expired")
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -32,6 +38,12 @@ type Offer struct {
 	Details          string          `json:"details"`
 	EnabledFor       map[string]bool `json:"enabledFor"`
 	MerchantCategory string          `json:"merchantCategory"`
+
+	// Rules is an optional predicate tree evaluated against a
+	// transaction (and the customer's running state) instead of the
+	// fixed amount/category checks in isOfferApplicable. Offers created
+	// without Rules keep the legacy behavior.
+	Rules *RuleNode `json:"rules,omitempty"`
 }
 
 // Enable user for the particular offer
@@ -44,21 +56,39 @@ func (o *Offer) DisableForUser(userID string) {
 	o.EnabledFor[userID] = false
 }
 
-// Map of offer
-var offers map[string]*Offer
+// registry is the concurrency-safe in-memory cache of offers, kept in
+// sync with the configured Store and used as the fast path for reads and
+// offer evaluation.
+var registry = NewOfferRegistry()
+
+// store is the configured persistence backend. All mutations to offers
+// go through it so state survives restarts.
+var store Store
+
+// customerStates tracks per-customer running state (e.g. transaction
+// counts per merchant category) needed to evaluate milestone rules.
+var customerStates = NewCustomerStateTracker()
+
+// idempotency remembers the OfferDecision made for each TxnID so a
+// retried /create-transaction call doesn't re-evaluate (and potentially
+// double-apply) an offer.
+var idempotency = NewIdempotencyCache(10000, 24*time.Hour)
 
 func enableOfferHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	offerName := vars["offerName"]
 	userID := vars["userID"]
 
-	offer, exists := offers[offerName]
-	if !exists {
-		http.NotFound(w, r)
+	if _, exists := registry.Get(offerName); !exists {
+		writeError(w, ErrOfferNotFound, offerName)
 		return
 	}
 
-	offer.EnableForUser(userID)
+	if err := store.SetUserEnabled(r.Context(), offerName, userID, true); err != nil {
+		writeError(w, err, "")
+		return
+	}
+	registry.EnableForUser(offerName, userID)
 	fmt.Fprintf(w, "Offer '%s' enabled for user '%s'\n", offerName, userID)
 }
 
@@ -67,92 +97,196 @@ func disableOfferHandler(w http.ResponseWriter, r *http.Request) {
 	offerName := vars["offerName"]
 	userID := vars["userID"]
 
-	offer, exists := offers[offerName]
-	if !exists {
-		http.NotFound(w, r)
+	if _, exists := registry.Get(offerName); !exists {
+		writeError(w, ErrOfferNotFound, offerName)
 		return
 	}
 
-	offer.DisableForUser(userID)
+	if err := store.SetUserEnabled(r.Context(), offerName, userID, false); err != nil {
+		writeError(w, err, "")
+		return
+	}
+	registry.DisableForUser(offerName, userID)
 	fmt.Fprintf(w, "Offer '%s' disabled for user '%s'\n", offerName, userID)
 }
 
 func offersDetailsHandler(w http.ResponseWriter, r *http.Request) {
-	allOffers := make([]Offer, 0, len(offers))
-	for _, offer := range offers {
-		allOffers = append(allOffers, *offer)
-	}
-
-	json.NewEncoder(w).Encode(allOffers)
-}
-
-func isOfferApplicable(transaction Transaction, offer Offer) bool {
-	return transaction.Amount >= offer.MinAmount && transaction.MerchantCategory == offer.MerchantCategory && offer.EnabledFor[transaction.CustomerID]
+	json.NewEncoder(w).Encode(registry.Snapshot())
 }
 
-func ApplyBestOfferForTransaction(transaction Transaction, offers map[string]*Offer) (*Offer, error) {
-	var bestOffer *Offer
-	for _, offer := range offers {
-		if isOfferApplicable(transaction, *offer) {
-			if bestOffer == nil || offer.Outcome > bestOffer.Outcome {
-				bestOffer = offer
-			}
-		}
+func isOfferApplicable(transaction Transaction, offer Offer, state CustomerState) bool {
+	if !offer.EnabledFor[transaction.CustomerID] {
+		return false
 	}
 
-	if bestOffer != nil {
-		return bestOffer, nil
+	if offer.Rules != nil {
+		ok, err := offer.Rules.Evaluate(transaction, state)
+		if err != nil {
+			log.Printf("offer %q: rule evaluation error: %v", offer.ID, err)
+			return false
+		}
+		return ok
 	}
 
-	return nil, fmt.Errorf("no applicable offer found")
+	return transaction.Amount >= offer.MinAmount && transaction.MerchantCategory == offer.MerchantCategory
 }
 
 func createTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	var transaction Transaction
 	err := json.NewDecoder(r.Body).Decode(&transaction)
 	if err != nil {
-		http.Error(w, "Invalid transaction data", http.StatusBadRequest)
+		writeError(w, ErrInvalidTransaction, err.Error())
+		return
+	}
+	if transaction.TxnID == "" {
+		writeError(w, ErrInvalidTransaction, "txnId is required")
+		return
+	}
+
+	// Claim the TxnID before evaluating so a client retrying while the
+	// first request is still in flight waits for that evaluation instead
+	// of racing it and double-applying the offer.
+	var decision OfferDecision
+	for {
+		d, found, claimed := idempotency.Claim(transaction.TxnID)
+		if found {
+			decision = d
+			break
+		}
+		if claimed {
+			break
+		}
+		// The caller we waited on released its claim after an error
+		// (found=false, claimed=false); try to claim it ourselves.
+	}
+
+	if decision.TxnID != "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(decision)
 		return
 	}
 
-	bestOffer, err := ApplyBestOfferForTransaction(transaction, offers)
+	bestOffer, err := customerStates.EvaluateAndRecord(transaction.CustomerID, transaction.MerchantCategory, func(state CustomerState) (*Offer, error) {
+		return registry.ApplyBestOfferForTransaction(r.Context(), transaction, state)
+	})
 	if err != nil {
-		http.Error(w, "No applicable offer found", http.StatusNotFound)
+		idempotency.Release(transaction.TxnID)
+		writeError(w, err, "")
 		return
 	}
 
+	decision = OfferDecision{
+		TxnID:        transaction.TxnID,
+		OfferID:      bestOffer.ID,
+		RewardAmount: bestOffer.Outcome,
+		AppliedAt:    time.Now(),
+	}
+	idempotency.Put(transaction.TxnID, decision)
+
+	if err := store.AppendTransaction(r.Context(), TransactionRecord{
+		Transaction: transaction,
+		OfferID:     bestOffer.ID,
+		AppliedAt:   decision.AppliedAt,
+	}); err != nil {
+		log.Printf("failed to append transaction log: %v", err)
+	}
+
 	fmt.Printf("Applied Offer: %+v\n", *bestOffer)
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Transaction processed"))
+	json.NewEncoder(w).Encode(decision)
 }
 
 func createOfferHandler(w http.ResponseWriter, r *http.Request) {
 	var offer Offer
 	err := json.NewDecoder(r.Body).Decode(&offer)
 	if err != nil {
-		http.Error(w, "Invalid offer data", http.StatusBadRequest)
+		writeError(w, ErrInvalidOffer, err.Error())
 		return
 	}
+	if offer.EnabledFor == nil {
+		offer.EnabledFor = make(map[string]bool)
+	}
 
-	offers[offer.ID] = &offer
+	if err := store.CreateOffer(r.Context(), &offer); err != nil {
+		writeError(w, err, "")
+		return
+	}
+	registry.Put(&offer)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Offer created"))
 }
 
+// newStore builds the configured Store backend. backend is one of
+// "memory", "postgres", or "redis"; dsn is the backend-specific
+// connection string (unused for "memory").
+func newStore(backend, dsn string) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewInMemoryStore(), nil
+	case "postgres":
+		return NewPostgresStore(dsn)
+	case "redis":
+		return NewRedisStore(dsn)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 func main() {
-	offers = make(map[string]*Offer)
+	storeFlag := flag.String("store", envOrDefault("OFFERING_STORE", "memory"), "storage backend: memory, postgres, or redis")
+	dsnFlag := flag.String("store-dsn", envOrDefault("OFFERING_STORE_DSN", ""), "connection string for the postgres/redis backend")
+	flag.Parse()
+
+	var err error
+	store, err = newStore(*storeFlag, *dsnFlag)
+	if err != nil {
+		log.Fatalf("failed to initialize store %q: %v", *storeFlag, err)
+	}
+
+	loaded, err := store.LoadOffers(context.Background())
+	if err != nil {
+		log.Fatalf("failed to reload offers from store: %v", err)
+	}
+	registry.LoadAll(loaded)
 
 	r := mux.NewRouter()
-	r.HandleFunc("/enable/{offerName}/{userID}", enableOfferHandler).Methods("POST")
-	r.HandleFunc("/disable/{offerName}/{userID}", disableOfferHandler).Methods("POST")
-	r.HandleFunc("/offers", offersDetailsHandler).Methods("GET")
-	r.HandleFunc("/create-offer", createOfferHandler).Methods("POST")
+	r.HandleFunc("/enable/{offerName}/{userID}", withTimeout(enableOfferHandler)).Methods("POST")
+	r.HandleFunc("/disable/{offerName}/{userID}", withTimeout(disableOfferHandler)).Methods("POST")
+	r.HandleFunc("/offers", withTimeout(offersDetailsHandler)).Methods("GET")
+	r.HandleFunc("/create-offer", withTimeout(createOfferHandler)).Methods("POST")
+	r.HandleFunc("/create-transaction", withTimeout(createTransactionHandler)).Methods("POST")
 
-	r.HandleFunc("/create-transaction", createTransactionHandler).Methods("POST")
+	srv := newServer(":8080", r)
 
-	http.Handle("/", r)
-	fmt.Println("Server is running on :8080")
-	http.ListenAndServe(":8080", nil)
+	go func() {
+		fmt.Printf("Server is running on :8080 (store=%s)\n", *storeFlag)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fmt.Println("Shutting down...")
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		log.Printf("failed to close store: %v", err)
+	}
 }
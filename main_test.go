@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCreateTransactionHandlerRejectsEmptyTxnID(t *testing.T) {
+	store = NewInMemoryStore()
+	registry = NewOfferRegistry()
+
+	body, _ := json.Marshal(Transaction{CustomerID: "cust-1", Amount: 100, MerchantCategory: "grocery"})
+	req := httptest.NewRequest(http.MethodPost, "/create-transaction", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	createTransactionHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	var resp errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Code != "invalid_transaction" {
+		t.Fatalf("got code %q, want %q", resp.Code, "invalid_transaction")
+	}
+}
+
+// TestCreateTransactionHandlerDeduplicatesConcurrentRetries reproduces a
+// client retrying the same TxnID while the first request is still being
+// evaluated: all concurrent copies must collapse onto a single applied
+// offer instead of each re-evaluating and double-applying it.
+func TestCreateTransactionHandlerDeduplicatesConcurrentRetries(t *testing.T) {
+	store = NewInMemoryStore()
+	registry = NewOfferRegistry()
+	customerStates = NewCustomerStateTracker()
+	idempotency = NewIdempotencyCache(10000, 24*time.Hour)
+
+	registry.Put(&Offer{
+		ID:               "offer-1",
+		Outcome:          5,
+		MinAmount:        100,
+		MerchantCategory: "grocery",
+		EnabledFor:       map[string]bool{"cust-1": true},
+	})
+
+	const concurrent = 20
+	body, _ := json.Marshal(Transaction{
+		TxnID:            "txn-retry",
+		CustomerID:       "cust-1",
+		Amount:           150,
+		MerchantCategory: "grocery",
+	})
+
+	var wg sync.WaitGroup
+	decisions := make([]OfferDecision, concurrent)
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/create-transaction", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+			createTransactionHandler(rec, req)
+			_ = json.Unmarshal(rec.Body.Bytes(), &decisions[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, d := range decisions {
+		if d != decisions[0] {
+			t.Fatalf("decision %d = %+v, want %+v (all retries must return the same decision)", i, d, decisions[0])
+		}
+	}
+
+	state := customerStates.entryFor("cust-1").state
+	if got := state.TxnCountByCategory["grocery"]; got != 1 {
+		t.Fatalf("got %d recorded transactions for a deduplicated TxnID, want 1", got)
+	}
+}
+
+// TestCreateOfferThenEnableDoesNotAliasEnabledFor reproduces the store/
+// registry aliasing bug: createOfferHandler must hand the registry and
+// the store independent EnabledFor maps, or concurrent enable/disable
+// calls end up writing the same map under two different locks and crash
+// with "concurrent map writes" under -race.
+func TestCreateOfferThenEnableDoesNotAliasEnabledFor(t *testing.T) {
+	store = NewInMemoryStore()
+	registry = NewOfferRegistry()
+
+	body, _ := json.Marshal(Offer{
+		ID:               "promo-1",
+		MerchantCategory: "grocery",
+		MinAmount:        100,
+		Outcome:          5,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/create-offer", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	createOfferHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create-offer got status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	// Bulk-enable/disable a handful of users concurrently, each hit
+	// repeatedly by several goroutines, so any aliasing between the
+	// store's and the registry's EnabledFor map shows up as a
+	// same-address conflict under -race (a single pass per user, each
+	// touching a distinct map bucket, is too sparse for the detector to
+	// reliably catch).
+	const (
+		users         = 4
+		goroutinesPer = 8
+		iterations    = 3000
+	)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for u := 0; u < users; u++ {
+		userID := fmt.Sprintf("user-%d", u)
+		for i := 0; i < goroutinesPer; i++ {
+			enable := i%2 == 0
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < iterations; j++ {
+					if enable {
+						req := httptest.NewRequest(http.MethodPost, "/enable/promo-1/"+userID, nil)
+						req = mux.SetURLVars(req, map[string]string{"offerName": "promo-1", "userID": userID})
+						enableOfferHandler(httptest.NewRecorder(), req)
+					} else {
+						req := httptest.NewRequest(http.MethodPost, "/disable/promo-1/"+userID, nil)
+						req = mux.SetURLVars(req, map[string]string{"offerName": "promo-1", "userID": userID})
+						disableOfferHandler(httptest.NewRecorder(), req)
+					}
+				}
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+}
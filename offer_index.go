@@ -0,0 +1,78 @@
+package main
+
+import "sort"
+
+// offerIndex speeds up ApplyBestOfferForTransaction for offers using the
+// legacy MinAmount/MerchantCategory check (no Rules): a slice per
+// merchant category, sorted ascending by MinAmount, so the offers
+// eligible on amount can be found with a binary search instead of a
+// linear scan over every offer. Rule-based offers aren't indexable this
+// way (a rule tree can test anything) and are kept in a separate bucket
+// evaluated linearly.
+type offerIndex struct {
+	byCategory map[string][]*Offer
+	ruled      map[string]*Offer
+}
+
+func newOfferIndex() *offerIndex {
+	return &offerIndex{
+		byCategory: make(map[string][]*Offer),
+		ruled:      make(map[string]*Offer),
+	}
+}
+
+// insert adds offer to the appropriate bucket, keeping byCategory sorted
+// by MinAmount.
+func (idx *offerIndex) insert(offer *Offer) {
+	if offer.Rules != nil {
+		idx.ruled[offer.ID] = offer
+		return
+	}
+
+	bucket := idx.byCategory[offer.MerchantCategory]
+	pos := sort.Search(len(bucket), func(i int) bool { return bucket[i].MinAmount >= offer.MinAmount })
+	bucket = append(bucket, nil)
+	copy(bucket[pos+1:], bucket[pos:])
+	bucket[pos] = offer
+	idx.byCategory[offer.MerchantCategory] = bucket
+}
+
+// remove deletes offerID (and its prior offer, if any) from whichever
+// bucket it lives in, so replacing an offer doesn't leave a stale entry
+// behind.
+func (idx *offerIndex) remove(offerID string) {
+	delete(idx.ruled, offerID)
+	for category, bucket := range idx.byCategory {
+		for i, offer := range bucket {
+			if offer.ID == offerID {
+				idx.byCategory[category] = append(bucket[:i], bucket[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// bestMatch returns the highest-Outcome offer applicable to transaction,
+// across both the amount-indexed bucket for its category and the
+// rule-based offers.
+func (idx *offerIndex) bestMatch(transaction Transaction, state CustomerState) *Offer {
+	var best *Offer
+
+	bucket := idx.byCategory[transaction.MerchantCategory]
+	// Every offer before `end` has MinAmount <= transaction.Amount.
+	end := sort.Search(len(bucket), func(i int) bool { return bucket[i].MinAmount > transaction.Amount })
+	for i := 0; i < end; i++ {
+		offer := bucket[i]
+		if isOfferApplicable(transaction, *offer, state) && (best == nil || offer.Outcome > best.Outcome) {
+			best = offer
+		}
+	}
+
+	for _, offer := range idx.ruled {
+		if isOfferApplicable(transaction, *offer, state) && (best == nil || offer.Outcome > best.Outcome) {
+			best = offer
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// OfferRegistry is the concurrency-safe in-memory cache of offers that
+// handlers read and mutate. A bare map guarded by nothing races under
+// concurrent HTTP requests, so every access goes through the registry's
+// RWMutex instead of touching the map or an Offer's EnabledFor directly.
+type OfferRegistry struct {
+	mu     sync.RWMutex
+	offers map[string]*Offer
+	index  *offerIndex
+}
+
+// NewOfferRegistry returns an empty registry.
+func NewOfferRegistry() *OfferRegistry {
+	return &OfferRegistry{offers: make(map[string]*Offer), index: newOfferIndex()}
+}
+
+// Put inserts or replaces the offer under its ID.
+func (reg *OfferRegistry) Put(offer *Offer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.index.remove(offer.ID)
+	reg.offers[offer.ID] = offer
+	reg.index.insert(offer)
+}
+
+// Get returns the offer with the given ID, or false if it does not exist.
+func (reg *OfferRegistry) Get(id string) (*Offer, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	offer, ok := reg.offers[id]
+	return offer, ok
+}
+
+// LoadAll replaces the registry's contents, e.g. after reloading from a
+// Store at startup.
+func (reg *OfferRegistry) LoadAll(offers map[string]*Offer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.offers = offers
+	reg.index = newOfferIndex()
+	for _, offer := range offers {
+		reg.index.insert(offer)
+	}
+}
+
+// Snapshot returns a deep copy of every offer currently in the registry.
+// EnabledFor is copied rather than shared so a caller (e.g. json.Marshal
+// in offersDetailsHandler) can read the result after the lock is
+// released without racing EnableForUser/DisableForUser.
+func (reg *OfferRegistry) Snapshot() []Offer {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	out := make([]Offer, 0, len(reg.offers))
+	for _, offer := range reg.offers {
+		copied := *offer
+		copied.EnabledFor = make(map[string]bool, len(offer.EnabledFor))
+		for userID, enabled := range offer.EnabledFor {
+			copied.EnabledFor[userID] = enabled
+		}
+		out = append(out, copied)
+	}
+	return out
+}
+
+// EnableForUser enables offerID for userID, returning false if the offer
+// does not exist.
+func (reg *OfferRegistry) EnableForUser(offerID, userID string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	offer, ok := reg.offers[offerID]
+	if !ok {
+		return false
+	}
+	offer.EnableForUser(userID)
+	return true
+}
+
+// DisableForUser disables offerID for userID, returning false if the
+// offer does not exist.
+func (reg *OfferRegistry) DisableForUser(offerID, userID string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	offer, ok := reg.offers[offerID]
+	if !ok {
+		return false
+	}
+	offer.DisableForUser(userID)
+	return true
+}
+
+// ApplyBestOfferForTransaction finds the highest-outcome offer applicable
+// to transaction, via the category/amount index instead of scanning
+// every offer. It holds a read lock for the whole evaluation so an
+// EnableForUser/DisableForUser call can't race with the EnabledFor reads
+// isOfferApplicable performs underneath it.
+func (reg *OfferRegistry) ApplyBestOfferForTransaction(ctx context.Context, transaction Transaction, state CustomerState) (*Offer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	bestOffer := reg.index.bestMatch(transaction, state)
+	if bestOffer == nil {
+		return nil, ErrNoApplicableOffer
+	}
+
+	copied := *bestOffer
+	return &copied, nil
+}
+
+// applyBestOfferLinear is the pre-index O(N) scan, kept only to
+// benchmark against the indexed path.
+func (reg *OfferRegistry) applyBestOfferLinear(transaction Transaction, state CustomerState) (*Offer, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	var bestOffer *Offer
+	for _, offer := range reg.offers {
+		if isOfferApplicable(transaction, *offer, state) {
+			if bestOffer == nil || offer.Outcome > bestOffer.Outcome {
+				bestOffer = offer
+			}
+		}
+	}
+
+	if bestOffer == nil {
+		return nil, ErrNoApplicableOffer
+	}
+
+	copied := *bestOffer
+	return &copied, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestOfferRegistryConcurrentAccess(t *testing.T) {
+	reg := NewOfferRegistry()
+
+	const (
+		numOffers = 20
+		numUsers  = 50
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numOffers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reg.Put(&Offer{
+				ID:               fmt.Sprintf("offer-%d", i),
+				Outcome:          float64(i),
+				MinAmount:        100,
+				MerchantCategory: "grocery",
+				EnabledFor:       make(map[string]bool),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < numUsers; i++ {
+		wg.Add(3)
+		userID := fmt.Sprintf("user-%d", i)
+		offerID := fmt.Sprintf("offer-%d", i%numOffers)
+
+		go func() {
+			defer wg.Done()
+			reg.EnableForUser(offerID, userID)
+		}()
+		go func() {
+			defer wg.Done()
+			reg.DisableForUser(offerID, userID)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = reg.ApplyBestOfferForTransaction(context.Background(), Transaction{
+				Amount:           150,
+				MerchantCategory: "grocery",
+				CustomerID:       userID,
+			}, CustomerState{TxnCountByCategory: map[string]int{}})
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := json.Marshal(reg.Snapshot()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func newBenchRegistry(n int) *OfferRegistry {
+	reg := NewOfferRegistry()
+	categories := []string{"grocery", "fuel", "travel", "dining", "electronics"}
+	for i := 0; i < n; i++ {
+		reg.Put(&Offer{
+			ID:               fmt.Sprintf("offer-%d", i),
+			Outcome:          float64(i % 100),
+			MinAmount:        (i % 20) * 50,
+			MerchantCategory: categories[i%len(categories)],
+			EnabledFor:       map[string]bool{"user-1": true},
+		})
+	}
+	return reg
+}
+
+func BenchmarkApplyBestOfferForTransaction(b *testing.B) {
+	reg := newBenchRegistry(10000)
+	txn := Transaction{Amount: 500, MerchantCategory: "grocery", CustomerID: "user-1"}
+	state := CustomerState{TxnCountByCategory: map[string]int{}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := reg.ApplyBestOfferForTransaction(context.Background(), txn, state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyBestOfferForTransactionLinear(b *testing.B) {
+	reg := newBenchRegistry(10000)
+	txn := Transaction{Amount: 500, MerchantCategory: "grocery", CustomerID: "user-1"}
+	state := CustomerState{TxnCountByCategory: map[string]int{}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := reg.applyBestOfferLinear(txn, state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RuleNode is a node in a boolean predicate tree used to decide whether
+// an offer applies to a transaction. Exactly one of And, Or, Not, or
+// Condition is expected to be set; an empty node matches everything,
+// which keeps offers with no Rules backward compatible.
+type RuleNode struct {
+	And       []RuleNode     `json:"and,omitempty"`
+	Or        []RuleNode     `json:"or,omitempty"`
+	Not       *RuleNode      `json:"not,omitempty"`
+	Condition *RuleCondition `json:"condition,omitempty"`
+}
+
+// RuleCondition is a leaf predicate evaluated against a single
+// Transaction field, or against the customer's running state for
+// "milestone" rules.
+type RuleCondition struct {
+	// Field is one of: amount, merchantId, merchantCategory,
+	// postEntryMode, hourOfDay, dayOfWeek, milestone.
+	Field string `json:"field"`
+
+	// Op is one of: eq, neq, gte, lte, between, in.
+	Op string `json:"op"`
+
+	// Value is op/field-specific: a number for eq/neq/gte/lte, a
+	// [min, max] pair for between, or a list for in.
+	Value json.RawMessage `json:"value"`
+}
+
+// Evaluate walks the predicate tree against transaction and state,
+// returning whether the offer's rules are satisfied.
+func (n RuleNode) Evaluate(transaction Transaction, state CustomerState) (bool, error) {
+	switch {
+	case len(n.And) > 0:
+		for _, child := range n.And {
+			ok, err := child.Evaluate(transaction, state)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case len(n.Or) > 0:
+		for _, child := range n.Or {
+			ok, err := child.Evaluate(transaction, state)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case n.Not != nil:
+		ok, err := n.Not.Evaluate(transaction, state)
+		return !ok, err
+
+	case n.Condition != nil:
+		return n.Condition.evaluate(transaction, state)
+
+	default:
+		return true, nil
+	}
+}
+
+func (c RuleCondition) evaluate(transaction Transaction, state CustomerState) (bool, error) {
+	switch c.Field {
+	case "amount":
+		return evalNumber(c.Op, c.Value, float64(transaction.Amount))
+	case "merchantId":
+		return evalString(c.Op, c.Value, transaction.MerchantID)
+	case "merchantCategory":
+		return evalString(c.Op, c.Value, transaction.MerchantCategory)
+	case "postEntryMode":
+		return evalString(c.Op, c.Value, transaction.PostEntryMode)
+	case "hourOfDay":
+		return evalNumber(c.Op, c.Value, float64(transaction.Timestamp.Hour()))
+	case "dayOfWeek":
+		return evalString(c.Op, c.Value, transaction.Timestamp.Weekday().String())
+	case "milestone":
+		ordinal := state.TxnCountByCategory[transaction.MerchantCategory] + 1
+		return evalNumber(c.Op, c.Value, float64(ordinal))
+	default:
+		return false, fmt.Errorf("unknown rule field %q", c.Field)
+	}
+}
+
+func evalNumber(op string, raw json.RawMessage, actual float64) (bool, error) {
+	switch op {
+	case "between":
+		var bounds [2]float64
+		if err := json.Unmarshal(raw, &bounds); err != nil {
+			return false, fmt.Errorf("decode between bounds: %w", err)
+		}
+		return actual >= bounds[0] && actual <= bounds[1], nil
+	default:
+		var want float64
+		if err := json.Unmarshal(raw, &want); err != nil {
+			return false, fmt.Errorf("decode numeric value: %w", err)
+		}
+		switch op {
+		case "eq":
+			return actual == want, nil
+		case "neq":
+			return actual != want, nil
+		case "gte":
+			return actual >= want, nil
+		case "lte":
+			return actual <= want, nil
+		default:
+			return false, fmt.Errorf("unknown numeric op %q", op)
+		}
+	}
+}
+
+func evalString(op string, raw json.RawMessage, actual string) (bool, error) {
+	switch op {
+	case "in":
+		var options []string
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return false, fmt.Errorf("decode in-set value: %w", err)
+		}
+		for _, option := range options {
+			if option == actual {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		var want string
+		if err := json.Unmarshal(raw, &want); err != nil {
+			return false, fmt.Errorf("decode string value: %w", err)
+		}
+		switch op {
+		case "eq":
+			return actual == want, nil
+		case "neq":
+			return actual != want, nil
+		default:
+			return false, fmt.Errorf("unknown string op %q", op)
+		}
+	}
+}
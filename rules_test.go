@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func mustRule(t *testing.T, raw string) RuleNode {
+	t.Helper()
+	var node RuleNode
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		t.Fatalf("unmarshal rule: %v", err)
+	}
+	return node
+}
+
+func TestRuleNodeEvaluate(t *testing.T) {
+	saturday := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC) // a Saturday
+
+	rule := mustRule(t, `{
+		"and": [
+			{"condition": {"field": "amount", "op": "gte", "value": 500}},
+			{"condition": {"field": "merchantCategory", "op": "eq", "value": "grocery"}},
+			{"condition": {"field": "dayOfWeek", "op": "in", "value": ["Saturday", "Sunday"]}},
+			{"condition": {"field": "milestone", "op": "gte", "value": 3}}
+		]
+	}`)
+
+	txn := Transaction{Amount: 600, MerchantCategory: "grocery", Timestamp: saturday}
+
+	ok, err := rule.Evaluate(txn, CustomerState{TxnCountByCategory: map[string]int{"grocery": 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected rule to fail before the 3rd grocery transaction")
+	}
+
+	ok, err = rule.Evaluate(txn, CustomerState{TxnCountByCategory: map[string]int{"grocery": 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected rule to match on the 3rd grocery transaction")
+	}
+}
+
+func TestRuleNodeNot(t *testing.T) {
+	rule := mustRule(t, `{"not": {"condition": {"field": "postEntryMode", "op": "eq", "value": "manual"}}}`)
+
+	ok, err := rule.Evaluate(Transaction{PostEntryMode: "chip"}, CustomerState{})
+	if err != nil || !ok {
+		t.Fatalf("expected non-manual entry mode to pass, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = rule.Evaluate(Transaction{PostEntryMode: "manual"}, CustomerState{})
+	if err != nil || ok {
+		t.Fatalf("expected manual entry mode to fail, got ok=%v err=%v", ok, err)
+	}
+}
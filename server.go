@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const requestTimeout = 5 * time.Second
+
+// withTimeout wraps next so its request context carries a deadline.
+// Handlers pass that context into the registry and store so a slow
+// lookup or DB query is cancelled instead of holding the connection
+// open past requestTimeout.
+func withTimeout(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// newServer builds the http.Server the app listens with. Bare
+// http.ListenAndServe has no timeouts, so a slow or malicious client can
+// hold a connection open indefinitely.
+func newServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
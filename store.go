@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TransactionRecord is an append-only log entry recording which offer (if
+// any) was applied to a processed transaction.
+type TransactionRecord struct {
+	Transaction Transaction `json:"transaction"`
+	OfferID     string      `json:"offerId"`
+	AppliedAt   time.Time   `json:"appliedAt"`
+}
+
+// Store persists offers, per-user enablement state, and the transaction
+// log so the service survives restarts. Implementations must be safe for
+// concurrent use. Every method takes a context so a backend that talks
+// to a real database can cancel slow queries when the caller's request
+// deadline expires.
+type Store interface {
+	// CreateOffer persists a new offer, replacing any existing offer with
+	// the same ID.
+	CreateOffer(ctx context.Context, offer *Offer) error
+
+	// GetOffer returns the offer with the given ID, or false if it does
+	// not exist.
+	GetOffer(ctx context.Context, id string) (*Offer, bool, error)
+
+	// LoadOffers returns every persisted offer, keyed by ID. It is called
+	// once at startup to rebuild in-memory state.
+	LoadOffers(ctx context.Context) (map[string]*Offer, error)
+
+	// SetUserEnabled persists whether userID is enabled for offerID.
+	SetUserEnabled(ctx context.Context, offerID, userID string, enabled bool) error
+
+	// AppendTransaction appends a record to the transaction log.
+	AppendTransaction(ctx context.Context, record TransactionRecord) error
+
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// InMemoryStore is a Store backed by a process-local map. State does not
+// survive restarts; it exists for local development and tests.
+type InMemoryStore struct {
+	mu     sync.RWMutex
+	offers map[string]*Offer
+	log    []TransactionRecord
+}
+
+// NewInMemoryStore returns an empty InMemoryStore.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{offers: make(map[string]*Offer)}
+}
+
+// cloneOffer returns a copy of offer with its own EnabledFor map, so the
+// caller and the store never end up sharing (and racing on) the same
+// backing map. Mirrors the deep-copy OfferRegistry.Snapshot does.
+func cloneOffer(offer Offer) Offer {
+	cloned := offer
+	cloned.EnabledFor = make(map[string]bool, len(offer.EnabledFor))
+	for userID, enabled := range offer.EnabledFor {
+		cloned.EnabledFor[userID] = enabled
+	}
+	return cloned
+}
+
+func (s *InMemoryStore) CreateOffer(ctx context.Context, offer *Offer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := cloneOffer(*offer)
+	s.offers[offer.ID] = &stored
+	return nil
+}
+
+func (s *InMemoryStore) GetOffer(ctx context.Context, id string) (*Offer, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	offer, ok := s.offers[id]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := cloneOffer(*offer)
+	return &copied, true, nil
+}
+
+func (s *InMemoryStore) LoadOffers(ctx context.Context) (map[string]*Offer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]*Offer, len(s.offers))
+	for id, offer := range s.offers {
+		copied := cloneOffer(*offer)
+		out[id] = &copied
+	}
+	return out, nil
+}
+
+func (s *InMemoryStore) SetUserEnabled(ctx context.Context, offerID, userID string, enabled bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offer, ok := s.offers[offerID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOfferNotFound, offerID)
+	}
+	if offer.EnabledFor == nil {
+		offer.EnabledFor = make(map[string]bool)
+	}
+	offer.EnabledFor[userID] = enabled
+	return nil
+}
+
+func (s *InMemoryStore) AppendTransaction(ctx context.Context, record TransactionRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = append(s.log, record)
+	return nil
+}
+
+func (s *InMemoryStore) Close() error { return nil }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresStore is a Store backed by a PostgreSQL database. Offers are
+// stored as rows with a JSON column for EnabledFor so the schema doesn't
+// need to change as rule fields are added to Offer.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore opens a connection pool against dsn and ensures the
+// schema exists.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+
+	s := &PostgresStore{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PostgresStore) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS offers (
+			id                TEXT PRIMARY KEY,
+			data              JSONB NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS transaction_log (
+			id                BIGSERIAL PRIMARY KEY,
+			txn_id            TEXT NOT NULL,
+			offer_id          TEXT NOT NULL,
+			applied_at        TIMESTAMPTZ NOT NULL,
+			data              JSONB NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("migrate postgres schema: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) CreateOffer(ctx context.Context, offer *Offer) error {
+	data, err := json.Marshal(offer)
+	if err != nil {
+		return fmt.Errorf("marshal offer: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO offers (id, data) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data
+	`, offer.ID, data)
+	if err != nil {
+		return fmt.Errorf("insert offer: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetOffer(ctx context.Context, id string) (*Offer, bool, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM offers WHERE id = $1`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("select offer: %w", err)
+	}
+
+	var offer Offer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, false, fmt.Errorf("unmarshal offer: %w", err)
+	}
+	return &offer, true, nil
+}
+
+func (s *PostgresStore) LoadOffers(ctx context.Context) (map[string]*Offer, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM offers`)
+	if err != nil {
+		return nil, fmt.Errorf("select offers: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]*Offer)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan offer: %w", err)
+		}
+		var offer Offer
+		if err := json.Unmarshal(data, &offer); err != nil {
+			return nil, fmt.Errorf("unmarshal offer: %w", err)
+		}
+		out[offer.ID] = &offer
+	}
+	return out, rows.Err()
+}
+
+// SetUserEnabled flips a single key of the enabledFor JSON object with one
+// atomic UPDATE, rather than reading the offer, mutating it in Go, and
+// writing it back — a read-modify-write that would lose an update from a
+// concurrent SetUserEnabled call on the same offer.
+func (s *PostgresStore) SetUserEnabled(ctx context.Context, offerID, userID string, enabled bool) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE offers
+		SET data = jsonb_set(
+			jsonb_set(data, '{enabledFor}', COALESCE(data->'enabledFor', '{}'::jsonb), true),
+			ARRAY['enabledFor', $2],
+			to_jsonb($3::bool),
+			true
+		)
+		WHERE id = $1
+	`, offerID, userID, enabled)
+	if err != nil {
+		return fmt.Errorf("update offer enablement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrOfferNotFound, offerID)
+	}
+	return nil
+}
+
+func (s *PostgresStore) AppendTransaction(ctx context.Context, record TransactionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal transaction record: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO transaction_log (txn_id, offer_id, applied_at, data)
+		VALUES ($1, $2, $3, $4)
+	`, record.Transaction.TxnID, record.OfferID, record.AppliedAt, data)
+	if err != nil {
+		return fmt.Errorf("insert transaction record: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisOffersKey = "offers:Offering-System"
+	redisLogKey    = "transaction_log:Offering-System"
+)
+
+// redisEnabledForKey is the per-offer hash (userID -> "true"/"false")
+// backing Offer.EnabledFor. Keeping enablement out of the offer's JSON
+// blob lets SetUserEnabled flip one field with a single atomic HSet
+// instead of a read-modify-write of the whole blob.
+func redisEnabledForKey(offerID string) string {
+	return "offer:" + offerID + ":enabledFor"
+}
+
+// RedisStore is a Store backed by Redis. Offers are stored as JSON in a
+// single hash keyed by offer ID; the transaction log is an append-only
+// list of JSON-encoded TransactionRecords.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis instance at addr.
+func NewRedisStore(addr string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("ping redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// CreateOffer stores the offer's core fields, keeping enabledFor in its
+// own hash (see redisEnabledForKey) so it isn't clobbered by a concurrent
+// SetUserEnabled. Any enablement passed in offer.EnabledFor is seeded
+// into that hash.
+func (s *RedisStore) CreateOffer(ctx context.Context, offer *Offer) error {
+	core := *offer
+	core.EnabledFor = nil
+	data, err := json.Marshal(&core)
+	if err != nil {
+		return fmt.Errorf("marshal offer: %w", err)
+	}
+	if err := s.client.HSet(ctx, redisOffersKey, offer.ID, data).Err(); err != nil {
+		return fmt.Errorf("hset offer: %w", err)
+	}
+
+	if len(offer.EnabledFor) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(offer.EnabledFor))
+	for userID, enabled := range offer.EnabledFor {
+		fields[userID] = enabled
+	}
+	if err := s.client.HSet(ctx, redisEnabledForKey(offer.ID), fields).Err(); err != nil {
+		return fmt.Errorf("hset offer enablement: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) loadEnabledFor(ctx context.Context, offerID string) (map[string]bool, error) {
+	raw, err := s.client.HGetAll(ctx, redisEnabledForKey(offerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("hgetall offer enablement: %w", err)
+	}
+
+	enabledFor := make(map[string]bool, len(raw))
+	for userID, value := range raw {
+		enabledFor[userID] = value == "1" || value == "true"
+	}
+	return enabledFor, nil
+}
+
+func (s *RedisStore) GetOffer(ctx context.Context, id string) (*Offer, bool, error) {
+	data, err := s.client.HGet(ctx, redisOffersKey, id).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("hget offer: %w", err)
+	}
+
+	var offer Offer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, false, fmt.Errorf("unmarshal offer: %w", err)
+	}
+
+	enabledFor, err := s.loadEnabledFor(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	offer.EnabledFor = enabledFor
+	return &offer, true, nil
+}
+
+func (s *RedisStore) LoadOffers(ctx context.Context) (map[string]*Offer, error) {
+	all, err := s.client.HGetAll(ctx, redisOffersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("hgetall offers: %w", err)
+	}
+
+	out := make(map[string]*Offer, len(all))
+	for id, data := range all {
+		var offer Offer
+		if err := json.Unmarshal([]byte(data), &offer); err != nil {
+			return nil, fmt.Errorf("unmarshal offer %q: %w", id, err)
+		}
+
+		enabledFor, err := s.loadEnabledFor(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		offer.EnabledFor = enabledFor
+		out[id] = &offer
+	}
+	return out, nil
+}
+
+// SetUserEnabled flips a single field of the offer's enabledFor hash
+// with one atomic HSet, rather than reading the offer, mutating it in
+// Go, and writing it back — a read-modify-write that would lose an
+// update from a concurrent SetUserEnabled call on the same offer.
+func (s *RedisStore) SetUserEnabled(ctx context.Context, offerID, userID string, enabled bool) error {
+	exists, err := s.client.HExists(ctx, redisOffersKey, offerID).Result()
+	if err != nil {
+		return fmt.Errorf("check offer exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrOfferNotFound, offerID)
+	}
+
+	if err := s.client.HSet(ctx, redisEnabledForKey(offerID), userID, enabled).Err(); err != nil {
+		return fmt.Errorf("hset offer enablement: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) AppendTransaction(ctx context.Context, record TransactionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal transaction record: %w", err)
+	}
+	if err := s.client.RPush(ctx, redisLogKey, data).Err(); err != nil {
+		return fmt.Errorf("rpush transaction record: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}